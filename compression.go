@@ -0,0 +1,280 @@
+package staticserver
+
+import (
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+//Encoder produces an io.WriteCloser that compresses bytes written to it
+//using a particular content-coding (eg. "gzip"). Closing the writer must
+//flush any buffered output
+type Encoder interface {
+	NewWriter(w io.Writer) io.WriteCloser
+}
+
+//gzipEncoder adapts compress/gzip to the Encoder interface and is always
+//available under the "gzip" encoding, even if CompressionOptions.Encoders is
+//nil
+type gzipEncoder struct{}
+
+func (gzipEncoder) NewWriter(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }
+
+//precompressedExt maps a content-coding to the file extension StaticServer
+//looks for when searching for a precompressed sibling asset, eg. requesting
+//"/app.js" with "Accept-Encoding: br" looks for "/app.js.br"
+var precompressedExt = map[string]string{
+	"gzip": "gz",
+	"br":   "br",
+	"zstd": "zst",
+}
+
+//CompressionOptions configures StaticServer's compression support. Set
+//StaticServer.Compression to enable it. "gzip" is always usable via
+//compress/gzip; other encodings (eg. "br", "zstd") are served from
+//precompressed sibling assets, and are only compressed on the fly with a
+//matching Encoder registered in Encoders, since the standard library doesn't implement them -
+//wrap a third-party package such as andybalholm/brotli or
+//klauspost/compress/zstd to supply one
+type CompressionOptions struct {
+	//Encodings lists the content-codings StaticServer may use, in
+	//preference order, eg. []string{"br", "gzip"}
+	Encodings []string
+	//MinSize is the minimum response size, in bytes, eligible for
+	//on-the-fly compression. Precompressed sibling assets are always
+	//served regardless of size
+	MinSize int
+	//Types is an allowlist of MIME types (or "type/*" prefixes) eligible
+	//for compression, eg. []string{"text/*", "application/javascript"}
+	Types []string
+	//Encoders maps a content-coding to the Encoder used to compress it
+	//on-the-fly. "gzip" is always available even if Encoders is nil or
+	//doesn't contain a "gzip" entry
+	Encoders map[string]Encoder
+}
+
+func (c *CompressionOptions) encoder(encoding string) Encoder {
+	if enc, ok := c.Encoders[encoding]; ok {
+		return enc
+	}
+	if encoding == "gzip" {
+		return gzipEncoder{}
+	}
+	return nil
+}
+
+//typeAllowed reports whether contentType is covered by Types, eg. "text/*"
+//matches "text/html" and "text/plain"
+func (c *CompressionOptions) typeAllowed(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mt = contentType
+	}
+	for _, t := range c.Types {
+		if t == mt {
+			return true
+		}
+		if strings.HasSuffix(t, "*") && strings.HasPrefix(mt, strings.TrimSuffix(t, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+//negotiateEncoding picks the first of Encodings (in preference order) that
+//is both accepted by the client's Accept-Encoding header and either has a
+//registered on-the-fly Encoder or a precompressed sibling extension
+func (c *CompressionOptions) negotiateEncoding(acceptEncoding string) string {
+	accepted := parseAcceptEncoding(acceptEncoding)
+	for _, enc := range c.Encodings {
+		if !accepted[enc] {
+			continue
+		}
+		if _, ok := precompressedExt[enc]; ok || c.encoder(enc) != nil {
+			return enc
+		}
+	}
+	return ""
+}
+
+//parseAcceptEncoding parses an Accept-Encoding header into the set of
+//encodings the client accepts, ignoring any entry with a "q=0" quality value
+func parseAcceptEncoding(header string) map[string]bool {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		enc := strings.TrimSpace(fields[0])
+		if enc == "" {
+			continue
+		}
+		q := "1"
+		for _, f := range fields[1:] {
+			f = strings.TrimSpace(f)
+			if strings.HasPrefix(f, "q=") {
+				q = strings.TrimPrefix(f, "q=")
+			}
+		}
+		if q != "0" {
+			accepted[enc] = true
+		}
+	}
+	return accepted
+}
+
+//contentTypeFor returns the MIME type StaticServer would use for reqpath,
+//falling back to application/octet-stream if the extension is unrecognized
+func contentTypeFor(reqpath string) string {
+	if ct := mime.TypeByExtension(path.Ext(reqpath)); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
+
+//compressedETag derives an ETag for a compressed representation from the
+//ETag computeETag gives info and content (the plain asset when compressing
+//on the fly, the sibling file itself when serving a precompressed one), suffixed with
+//"-"+encoding so that a cache never confuses a compressed representation
+//with the plain one or with a different encoding of the same asset
+func compressedETag(info fs.FileInfo, content io.ReadSeeker, encoding string) (string, error) {
+	base, err := computeETag(info, content)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(base, `"`) + "-" + encoding + `"`, nil
+}
+
+//etagMatches reports whether etag appears in the comma separated list of
+//entries in an If-None-Match header, per RFC 7232 (including the "*"
+//wildcard and the weak-validator "W/" prefix)
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+//tryCompression serves reqpath compressed - either from a precompressed
+//sibling asset or by compressing it on the fly - if ss.Compression allows it
+//for this request. It reports whether it already wrote a response
+func (ss *StaticServer) tryCompression(w http.ResponseWriter, r *http.Request, reqpath string, info fs.FileInfo) bool {
+	c := ss.Compression
+	if c == nil || len(c.Encodings) == 0 {
+		return false
+	}
+
+	//ranges and compressed responses don't mix well, so let uncompressed
+	//serving (with full Range support) handle these requests instead
+	if r.Header.Get("Range") != "" {
+		return false
+	}
+
+	contentType := contentTypeFor(reqpath)
+	if !c.typeAllowed(contentType) {
+		return false
+	}
+
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	encoding := c.negotiateEncoding(r.Header.Get("Accept-Encoding"))
+	if encoding == "" {
+		return false
+	}
+
+	if ext, ok := precompressedExt[encoding]; ok {
+		if ss.servePrecompressed(w, r, reqpath, encoding, ext, contentType) {
+			return true
+		}
+	}
+
+	return ss.serveCompressedOnTheFly(w, r, reqpath, info, encoding, contentType)
+}
+
+//servePrecompressed looks for reqpath+"."+ext on the backing fs.FS (eg.
+//"app.js.br" for a "br" encoded request of "app.js") and serves it verbatim
+//with the appropriate Content-Encoding if found
+func (ss *StaticServer) servePrecompressed(w http.ResponseWriter, r *http.Request, reqpath, encoding, ext, contentType string) bool {
+	sibling := reqpath + "." + ext
+
+	info, err := fs.Stat(ss.fsys, sibling)
+	if err != nil || info.IsDir() {
+		return false
+	}
+
+	f, rds, err := openReadSeeker(ss.fsys, sibling)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	return ss.serveCompressedBody(w, r, info, rds, encoding, contentType, info.Size(), func(w io.Writer) {
+		io.Copy(w, rds)
+	})
+}
+
+//serveCompressedOnTheFly compresses reqpath using the Encoder registered for
+//encoding, if info.Size() meets CompressionOptions.MinSize
+func (ss *StaticServer) serveCompressedOnTheFly(w http.ResponseWriter, r *http.Request, reqpath string, info fs.FileInfo, encoding, contentType string) bool {
+	enc := ss.Compression.encoder(encoding)
+	if enc == nil || int(info.Size()) < ss.Compression.MinSize {
+		return false
+	}
+
+	f, rds, err := openReadSeeker(ss.fsys, reqpath)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	//the compressed size isn't known ahead of time, so omit Content-Length
+	//and let the client fall back to chunked transfer encoding
+	return ss.serveCompressedBody(w, r, info, rds, encoding, contentType, -1, func(w io.Writer) {
+		cw := enc.NewWriter(w)
+		io.Copy(cw, rds)
+		cw.Close()
+	})
+}
+
+//serveCompressedBody writes the headers common to both precompressed and
+//on-the-fly compressed responses, short-circuiting to a bodyless 304 if the
+//request's If-None-Match matches the content-derived ETag, and otherwise
+//writing a bodyless 200 for HEAD requests or invoking write for GET. It
+//reports whether it already wrote a response; a false return (only possible
+//if the ETag couldn't be computed) means the caller should fall back to
+//uncompressed serving
+func (ss *StaticServer) serveCompressedBody(w http.ResponseWriter, r *http.Request, info fs.FileInfo, rds io.ReadSeeker, encoding, contentType string, contentLength int64, write func(w io.Writer)) bool {
+	etag, err := compressedETag(info, rds, encoding)
+	if err != nil {
+		return false
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Encoding", encoding)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("ETag", etag)
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+
+	if contentLength >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(contentLength, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+
+	if r.Method != http.MethodHead {
+		write(w)
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+package staticserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCompressionOnTheFly(t *testing.T) {
+	body := strings.Repeat("hello world ", 200)
+	mapfs := fstest.MapFS{"app.js": {Data: []byte(body)}}
+	ss := New(mapfs, WithCompression(&CompressionOptions{
+		Encodings: []string{"gzip"},
+		MinSize:   10,
+		Types:     []string{"application/javascript", "text/*"},
+	}))
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/app.js", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	ss.ServeHTTP(w, r)
+
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("want Content-Encoding gzip, got %q", w.Header().Get("Content-Encoding"))
+	}
+	if w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("want Vary: Accept-Encoding, got %q", w.Header().Get("Vary"))
+	}
+
+	gr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("body was not valid gzip: %v", err)
+	}
+	got, err := io.ReadAll(gr)
+	if err != nil {
+		t.Fatalf("error reading gzip body: %v", err)
+	}
+	if string(got) != body {
+		t.Errorf("decompressed body did not match:\nwant:%s\ngot:%s", body, got)
+	}
+}
+
+func TestCompressionPrecompressedSibling(t *testing.T) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	gw.Write([]byte("precompressed content"))
+	gw.Close()
+
+	mapfs := fstest.MapFS{
+		"app.js":    {Data: []byte("precompressed content")},
+		"app.js.gz": {Data: buf.Bytes()},
+	}
+	ss := New(mapfs, WithCompression(&CompressionOptions{
+		Encodings: []string{"gzip"},
+		Types:     []string{"application/javascript", "text/*"},
+	}))
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/app.js", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	ss.ServeHTTP(w, r)
+
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("want Content-Encoding gzip, got %q", w.Header().Get("Content-Encoding"))
+	}
+	if w.Body.String() != buf.String() {
+		t.Errorf("want the precompressed sibling's bytes served verbatim")
+	}
+}
+
+func TestCompressionSkippedForDisallowedType(t *testing.T) {
+	mapfs := fstest.MapFS{"photo.png": {Data: bytes.Repeat([]byte{0xFF}, 2048)}}
+	ss := New(mapfs, WithCompression(&CompressionOptions{
+		Encodings: []string{"gzip"},
+		MinSize:   10,
+		Types:     []string{"text/*"},
+	}))
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/photo.png", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	ss.ServeHTTP(w, r)
+
+	if w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("want no compression for a disallowed MIME type, got Content-Encoding %q", w.Header().Get("Content-Encoding"))
+	}
+}
@@ -0,0 +1,54 @@
+package staticserver
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+//errFS is a fake fs.FS whose Open always fails with err, used to exercise
+//StaticServer's error classification without needing a real broken filesystem
+type errFS struct {
+	err error
+}
+
+func (e errFS) Open(name string) (fs.File, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: e.err}
+}
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{"not exist", fs.ErrNotExist, http.StatusNotFound},
+		{"permission", fs.ErrPermission, http.StatusForbidden},
+		{"other", fs.ErrClosed, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ss := FSStaticServer(errFS{err: tt.err}, nil, nil)
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("GET", "http://example.com/missing.txt", nil)
+			ss.ServeHTTP(w, r)
+			if w.Code != tt.wantStatus {
+				t.Errorf("want status %d got %d", tt.wantStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestCustomClassifyError(t *testing.T) {
+	ss := New(errFS{err: fs.ErrClosed}, WithClassifyError(func(err error) int {
+		return http.StatusTeapot
+	}))
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/missing.txt", nil)
+	ss.ServeHTTP(w, r)
+	if w.Code != http.StatusTeapot {
+		t.Errorf("want custom ClassifyError's status %d got %d", http.StatusTeapot, w.Code)
+	}
+}
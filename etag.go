@@ -0,0 +1,36 @@
+package staticserver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+//etagHashThreshold is the largest file size StaticServer will hash in full to
+//compute an ETag. Larger files are identified by size and modification time
+//instead, so that serving a large asset doesn't mean reading it twice on
+//every request just to produce a cache validator
+const etagHashThreshold = 1 << 20 // 1 MiB
+
+//computeETag returns a quoted, weak-free ETag value (suitable for the ETag
+//header and If-None-Match/If-Match comparisons) for the asset described by
+//info and content. Files up to etagHashThreshold are identified by a SHA-256
+//hash of their content; content is left seeked back to the start once hashed.
+//Larger files are identified by their size and modification time instead
+func computeETag(info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	if info.Size() > etagHashThreshold {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%d-%d", info.Size(), info.ModTime().UnixNano())))
+		return fmt.Sprintf("%q", hex.EncodeToString(sum[:16])), nil
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%q", hex.EncodeToString(h.Sum(nil)[:16])), nil
+}
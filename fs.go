@@ -0,0 +1,89 @@
+package staticserver
+
+import (
+	"embed"
+	"io"
+	"io/fs"
+	"os"
+)
+
+//OSFS returns an io/fs.FS rooted at root on the OS filesystem, suitable for
+//use with FSStaticServer. It is a thin wrapper around os.DirFS
+func OSFS(root string) fs.FS {
+	return os.DirFS(root)
+}
+
+//EmbedFS returns an io/fs.FS that serves the subdir directory of f, suitable
+//for use with FSStaticServer. It is a thin wrapper around fs.Sub that lets
+//callers keep their //go:embed directives at the package root (eg. "assets")
+//while serving the static server from the filesystem root
+func EmbedFS(f embed.FS, subdir string) (fs.FS, error) {
+	return fs.Sub(f, subdir)
+}
+
+//statReaderFS adapts a StatFunc/ReaderFunc pair - as used by the legacy
+//RawStaticServer and the godoc vfs based constructors - to the io/fs.FS
+//interface so that ServeHTTP only ever needs to deal with a single
+//abstraction. StatFunc and ReaderFunc both expect rooted, slash prefixed
+//paths (eg. "/index.html") while io/fs.FS paths are never rooted (eg.
+//"index.html", or "." for the root), so statReaderFS re-adds the leading
+//slash before delegating
+type statReaderFS struct {
+	stat     StatFunc
+	readerfn ReaderFunc
+}
+
+func (a statReaderFS) rootedPath(name string) string {
+	if name == "." {
+		return "/"
+	}
+	return "/" + name
+}
+
+func (a statReaderFS) Open(name string) (fs.File, error) {
+	rooted := a.rootedPath(name)
+
+	info, err := a.stat(rooted)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+
+	if info.IsDir() {
+		return &statReaderDir{info: info}, nil
+	}
+
+	rds, err := a.readerfn(rooted)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	return &statReaderFile{ReadSeeker: rds, info: info}, nil
+}
+
+//statReaderFile wraps the io.ReadSeeker returned by a ReaderFunc into an
+//fs.File, closing the underlying ReadSeeker on Close() if it supports it -
+//mirroring the behaviour StaticServer.ServeHTTP used to implement itself
+type statReaderFile struct {
+	io.ReadSeeker
+	info os.FileInfo
+}
+
+func (f *statReaderFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+
+func (f *statReaderFile) Close() error {
+	if c, ok := f.ReadSeeker.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+//statReaderDir represents a directory found via a StatFunc. StaticServer
+//never reads directory contents directly (it looks for an index.html
+//instead), so Read is not expected to be called
+type statReaderDir struct {
+	info os.FileInfo
+}
+
+func (d *statReaderDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *statReaderDir) Read([]byte) (int, error)   { return 0, io.EOF }
+func (d *statReaderDir) Close() error               { return nil }
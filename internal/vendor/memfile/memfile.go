@@ -0,0 +1,59 @@
+//Package memfile provides a minimal in-memory os.FileInfo/io.ReadSeekCloser
+//implementation used only by staticserver's own tests to exercise
+//RawStaticServer without touching a real filesystem. It mirrors the public
+//API of github.com/srinathh/memfile that those tests were written against;
+//it is vendored locally (see the replace directive in go.mod) because that
+//module isn't reachable from this environment
+package memfile
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+//SimpleMemFile is an os.FileInfo backed by an in-memory byte slice. Its Open
+//method satisfies staticserver.ReaderFunc, and Misopen reports whether the
+//file is currently open without a matching Close, so tests can assert
+//RawStaticServer closes what it opens
+type SimpleMemFile struct {
+	name    string
+	data    []byte
+	t       *testing.T
+	Misopen bool
+	r       *bytes.Reader
+}
+
+//NewSimpleMemFile returns a SimpleMemFile named name serving data. Misopen
+//starts false and flips to true on Open, back to false on Close
+func NewSimpleMemFile(name string, data []byte, t *testing.T) SimpleMemFile {
+	return SimpleMemFile{name: name, data: data, t: t}
+}
+
+//Open ignores name and returns m itself as an io.ReadSeeker positioned at
+//the start of data, satisfying staticserver.ReaderFunc
+func (m *SimpleMemFile) Open(name string) (io.ReadSeeker, error) {
+	m.Misopen = true
+	m.r = bytes.NewReader(m.data)
+	return m, nil
+}
+
+func (m *SimpleMemFile) Read(p []byte) (int, error) { return m.r.Read(p) }
+
+func (m *SimpleMemFile) Seek(offset int64, whence int) (int64, error) {
+	return m.r.Seek(offset, whence)
+}
+
+func (m *SimpleMemFile) Close() error {
+	m.Misopen = false
+	return nil
+}
+
+func (m *SimpleMemFile) Name() string       { return m.name }
+func (m *SimpleMemFile) Size() int64        { return int64(len(m.data)) }
+func (m *SimpleMemFile) Mode() os.FileMode  { return 0644 }
+func (m *SimpleMemFile) ModTime() time.Time { return time.Time{} }
+func (m *SimpleMemFile) IsDir() bool        { return false }
+func (m *SimpleMemFile) Sys() interface{}   { return nil }
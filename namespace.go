@@ -0,0 +1,132 @@
+package staticserver
+
+import (
+	"io/fs"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+)
+
+//BindMode controls how NameSpace.Bind composes a new backend with any
+//backends already bound at the same mount point
+type BindMode int
+
+const (
+	//BindReplace discards any backends previously bound at this mount point
+	BindReplace BindMode = iota
+	//BindBefore overlays the new backend in front of (ie. consulted before)
+	//any backends already bound at this mount point
+	BindBefore
+	//BindAfter overlays the new backend behind (ie. consulted after) any
+	//backends already bound at this mount point
+	BindAfter
+)
+
+//binding associates a mount point with one fs.FS backend, rooted at subdir
+//within that backend
+type binding struct {
+	fsys   fs.FS
+	subdir string
+}
+
+//NameSpace composes several fs.FS backends, each bound at its own URL path
+//prefix, into a single fs.FS - eg an embed.FS at "/assets/", a zip file at
+//"/docs/" and an OS directory at "/uploads/". Since NameSpace implements
+//fs.FS, it can be passed straight to FSStaticServer or New in place of a
+//single backend, replacing the ad-hoc http.StripPrefix chains this would
+//otherwise take. The zero value is not usable; create one with NewNameSpace
+type NameSpace struct {
+	binds map[string][]binding
+}
+
+//NewNameSpace returns an empty NameSpace ready for Bind calls
+func NewNameSpace() *NameSpace {
+	return &NameSpace{binds: make(map[string][]binding)}
+}
+
+//Bind mounts fsys at old, a URL path prefix (eg. "/assets/"), serving the
+//subdir directory of fsys (eg. "/" for its root) from that prefix. mode
+//controls how this backend composes with any backend already bound at old -
+//see BindMode. Binding several backends at the same prefix with BindBefore
+//or BindAfter overlays them, so that a request missing from one backend
+//falls through to the next
+func (ns *NameSpace) Bind(old string, fsys fs.FS, subdir string, mode BindMode) {
+	old = cleanMount(old)
+	b := binding{fsys: fsys, subdir: fsPath(subdir)}
+
+	switch mode {
+	case BindBefore:
+		ns.binds[old] = append([]binding{b}, ns.binds[old]...)
+	case BindAfter:
+		ns.binds[old] = append(ns.binds[old], b)
+	default: // BindReplace
+		ns.binds[old] = []binding{b}
+	}
+}
+
+//cleanMount normalizes a mount point to always start and end with "/"
+func cleanMount(old string) string {
+	if !strings.HasPrefix(old, "/") {
+		old = "/" + old
+	}
+	if !strings.HasSuffix(old, "/") {
+		old += "/"
+	}
+	return old
+}
+
+//resolve finds the longest bound prefix covering name (an fs.FS style path,
+//ie. without a leading slash, "." for the root) and returns the path
+//remaining under that prefix - again fs.FS style - along with its bindings
+func (ns *NameSpace) resolve(name string) (string, []binding) {
+	target := "/" + name
+	if name == "." {
+		target = "/"
+	}
+
+	var best string
+	for mount := range ns.binds {
+		if target != strings.TrimSuffix(mount, "/") && !strings.HasPrefix(target, mount) {
+			continue
+		}
+		if len(mount) > len(best) {
+			best = mount
+		}
+	}
+	if best == "" {
+		return "", nil
+	}
+
+	rest := strings.TrimPrefix(strings.TrimPrefix(target, strings.TrimSuffix(best, "/")), "/")
+	return rest, ns.binds[best]
+}
+
+//Open implements fs.FS. It delegates to the longest bound prefix covering
+//name, joining the remainder of the path under each overlaid backend's
+//subdir and trying each in turn until one succeeds
+func (ns *NameSpace) Open(name string) (fs.File, error) {
+	rest, binds := ns.resolve(name)
+	if len(binds) == 0 {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if rest == "" {
+		rest = "."
+	}
+
+	var lastErr error
+	for _, b := range binds {
+		f, err := b.fsys.Open(path.Join(b.subdir, rest))
+		if err == nil {
+			return f, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+//Handler returns a StaticServer serving ns, equivalent to
+//FSStaticServer(ns, errorHandlers, logger)
+func (ns *NameSpace) Handler(errorHandlers map[int]http.HandlerFunc, logger *log.Logger) StaticServer {
+	return FSStaticServer(ns, errorHandlers, logger)
+}
@@ -0,0 +1,44 @@
+package staticserver
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestNameSpace(t *testing.T) {
+	assets := fstest.MapFS{"app.js": {Data: []byte("assets app.js")}}
+	docs := fstest.MapFS{"index.html": {Data: []byte("docs index")}}
+
+	ns := NewNameSpace()
+	ns.Bind("/assets/", assets, "/", BindReplace)
+	ns.Bind("/docs/", docs, "/", BindReplace)
+
+	ss := ns.Handler(nil, nil)
+	tests := map[string]response{
+		"http://example.com/assets/app.js": response{200, "assets app.js"},
+		"http://example.com/docs/":         response{200, "docs index"},
+		"http://example.com/unmounted":     response{404, "Not Found\n"},
+	}
+	runTests(tests, ss, "TestNameSpace", t)
+}
+
+func TestNameSpaceOverlay(t *testing.T) {
+	base := fstest.MapFS{
+		"theme.css": {Data: []byte("default theme")},
+		"logo.png":  {Data: []byte("default logo")},
+	}
+	override := fstest.MapFS{
+		"theme.css": {Data: []byte("site theme")},
+	}
+
+	ns := NewNameSpace()
+	ns.Bind("/", base, "/", BindReplace)
+	ns.Bind("/", override, "/", BindBefore)
+
+	ss := ns.Handler(nil, nil)
+	tests := map[string]response{
+		"http://example.com/theme.css": response{200, "site theme"},
+		"http://example.com/logo.png":  response{200, "default logo"},
+	}
+	runTests(tests, ss, "TestNameSpaceOverlay", t)
+}
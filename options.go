@@ -0,0 +1,83 @@
+package staticserver
+
+import (
+	"io/fs"
+	"log"
+	"net/http"
+)
+
+//Option configures a StaticServer built via New
+type Option func(*StaticServer)
+
+//WithErrorHandlers sets custom http.HandlerFunc for specific status codes,
+//falling back to the package defaults for any status code not provided -
+//equivalent to the errorHandlers argument taken by the other constructors
+func WithErrorHandlers(errorHandlers map[int]http.HandlerFunc) Option {
+	return func(ss *StaticServer) {
+		ss.errorHandlers = setupErrorHandlers(errorHandlers)
+	}
+}
+
+//WithLogger sets the logger StaticServer uses for verbose request logging -
+//equivalent to the logger argument taken by the other constructors
+func WithLogger(logger *log.Logger) Option {
+	return func(ss *StaticServer) {
+		ss.logger = logger
+	}
+}
+
+//WithSPAFallback sets StaticServer.SPAFallback, the path (relative to the
+//backing fs.FS, eg. "index.html") served with a 200 status whenever a
+//request maps to a missing path and no directory index exists either - the
+//common single-page-app routing pattern
+func WithSPAFallback(path string) Option {
+	return func(ss *StaticServer) {
+		ss.SPAFallback = path
+	}
+}
+
+//WithErrorPages sets StaticServer.ErrorPages, a map of status code to path
+//(relative to the backing fs.FS, eg. "404.html") served - with that status
+//code - in preference to the matching errorHandlers entry
+func WithErrorPages(errorPages map[int]string) Option {
+	return func(ss *StaticServer) {
+		ss.ErrorPages = errorPages
+	}
+}
+
+//WithCompression sets StaticServer.Compression, enabling negotiated
+//on-the-fly and precompressed-asset compression - see CompressionOptions
+func WithCompression(opts *CompressionOptions) Option {
+	return func(ss *StaticServer) {
+		ss.Compression = opts
+	}
+}
+
+//WithClassifyError sets StaticServer.ClassifyError, overriding how errors
+//returned by the backing fs.FS are mapped to HTTP status codes - see
+//StaticServer.ClassifyError
+func WithClassifyError(classify func(error) int) Option {
+	return func(ss *StaticServer) {
+		ss.ClassifyError = classify
+	}
+}
+
+//New returns a StaticServer that serves static assets out of fsys, the
+//standard library's io/fs.FS abstraction, configured via the supplied
+//Options. It is equivalent to FSStaticServer but covers the SPA fallback and
+//error page cases without requiring a custom http.HandlerFunc, eg:
+//
+//	ss := staticserver.New(staticserver.OSFS("public"),
+//		staticserver.WithSPAFallback("index.html"),
+//		staticserver.WithErrorPages(map[int]string{404: "404.html"}),
+//	)
+func New(fsys fs.FS, opts ...Option) StaticServer {
+	ss := StaticServer{
+		fsys:          fsys,
+		errorHandlers: defaultErrorHandlers,
+	}
+	for _, opt := range opts {
+		opt(&ss)
+	}
+	return ss
+}
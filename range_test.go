@@ -0,0 +1,98 @@
+package staticserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRangeRequests(t *testing.T) {
+	content := []byte("0123456789abcdefghij") // 20 bytes
+	mapfs := fstest.MapFS{"file.txt": {Data: content}}
+	ss := FSStaticServer(mapfs, nil, nil)
+
+	tests := []struct {
+		name       string
+		rangeHdr   string
+		wantStatus int
+		wantBody   string
+	}{
+		{"single range", "bytes=0-4", http.StatusPartialContent, "01234"},
+		{"suffix range", "bytes=-5", http.StatusPartialContent, "fghij"},
+		{"open ended range", "bytes=15-", http.StatusPartialContent, "fghij"},
+		{"unsatisfiable range", "bytes=100-200", http.StatusRequestedRangeNotSatisfiable, ""},
+		{"malformed range", "bytes=banana", http.StatusRequestedRangeNotSatisfiable, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("GET", "http://example.com/file.txt", nil)
+			r.Header.Set("Range", tt.rangeHdr)
+			ss.ServeHTTP(w, r)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status: want %d got %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body: want %q got %q", tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRangeRequestsLegacyAdapter(t *testing.T) {
+	ss := MapSS(map[string]string{"file.txt": "0123456789abcdefghij"}, nil, nil)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/file.txt", nil)
+	r.Header.Set("Range", "bytes=0-4")
+	ss.ServeHTTP(w, r)
+
+	if w.Code != http.StatusPartialContent {
+		t.Fatalf("status: want %d got %d", http.StatusPartialContent, w.Code)
+	}
+	if w.Body.String() != "01234" {
+		t.Errorf("body: want %q got %q", "01234", w.Body.String())
+	}
+}
+
+func TestMultipartRange(t *testing.T) {
+	content := []byte("0123456789abcdefghij")
+	mapfs := fstest.MapFS{"file.txt": {Data: content}}
+	ss := FSStaticServer(mapfs, nil, nil)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/file.txt", nil)
+	r.Header.Set("Range", "bytes=0-1,5-8")
+	ss.ServeHTTP(w, r)
+
+	if w.Code != http.StatusPartialContent {
+		t.Fatalf("want 206 got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.Contains(ct, "multipart/byteranges") {
+		t.Errorf("want multipart/byteranges content type, got %s", ct)
+	}
+}
+
+func TestETagConditionalGet(t *testing.T) {
+	mapfs := fstest.MapFS{"file.txt": {Data: []byte("hello world")}}
+	ss := FSStaticServer(mapfs, nil, nil)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/file.txt", nil)
+	ss.ServeHTTP(w, r)
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected ETag header to be set")
+	}
+
+	w2 := httptest.NewRecorder()
+	r2, _ := http.NewRequest("GET", "http://example.com/file.txt", nil)
+	r2.Header.Set("If-None-Match", etag)
+	ss.ServeHTTP(w2, r2)
+	if w2.Code != http.StatusNotModified {
+		t.Errorf("want 304 got %d", w2.Code)
+	}
+}
@@ -1,5 +1,6 @@
 //Package staticserver implements a small flexible static assets server that can
-//serve godoc virtual file systems, go-bindata assets etc with custom errors
+//serve io/fs.FS filesystems (including embed.FS, os.DirFS and zip.Reader),
+//godoc virtual file systems, go-bindata assets etc with custom errors
 //handlers and no directory listing
 //
 //The primary motivation for creating this package was to build a static asset
@@ -7,24 +8,24 @@
 //drectory listing, custom error handlers) while getting flexibility to serve
 //static assets from a variety of sources.
 //
-//To achieve flexibility, StaticServer defines two function types - one with a
-//signature matching os.LStat (takes a path & returns os.FileInfo) and the other
-//with signature matching os.Open (takes a path & returns an os.ReadSeeker)
-//which it uses to access and serve static assets.
-//
-//OS Filesystems, [string]string Maps & Zip files can be served by abstracting
-//via godoc virtual file system and helper functions are provided to support them.
-//go-bindata assets are similarly served by wrapping Asset(), AssetInfo() etc.
-//in function closures
+//Since Go 1.16, the standard library's io/fs.FS is the idiomatic abstraction
+//for read-only filesystems, so FSStaticServer and its io/fs.FS backed helpers
+//(OSFS, EmbedFS) are now the preferred way to build a StaticServer. The older
+//StatFunc/ReaderFunc pair and the godoc vfs based constructors (VFSStaticServer,
+//OSSS, MapSS, ZipSS) are kept working by adapting them to an io/fs.FS under the
+//hood, so existing callers do not need to change anything.
 package staticserver
 
 import (
 	"archive/zip"
+	"bytes"
+	"errors"
 	"io"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
-	"path/filepath"
+	"path"
 
 	"golang.org/x/tools/godoc/vfs"
 	"golang.org/x/tools/godoc/vfs/mapfs"
@@ -35,6 +36,9 @@ var defaultErrorHandlers = map[int]http.HandlerFunc{
 	http.StatusNotFound: func(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Not Found", http.StatusNotFound)
 	},
+	http.StatusForbidden: func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+	},
 	http.StatusInternalServerError: func(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 	},
@@ -69,15 +73,48 @@ type StatFunc func(string) (os.FileInfo, error)
 type ReaderFunc func(string) (io.ReadSeeker, error)
 
 //StaticServer provides a http.Handler to serves static assets. Use one of the
-//"constructors" to get an instance of StaticServer to serve files from the
-//filesystem, go-bindata asset functions, maps etc. You can provide your custom
-//HandlerFuncs for http.StatusNotFound and http.StatusInternalServerError error
-//if you wish and it will use those instead of basic http.Error()
+//"constructors" to get an instance of StaticServer to serve files from an
+//io/fs.FS, the filesystem, go-bindata asset functions, maps etc. You can provide
+//your custom HandlerFuncs for http.StatusNotFound and http.StatusInternalServerError
+//error if you wish and it will use those instead of basic http.Error()
+//
+//SPAFallback and ErrorPages cover the common cases that would otherwise need a
+//custom http.HandlerFunc: SPAFallback, if set, names a path (relative to the
+//backing fs.FS, eg. "index.html") that is served with a 200 status whenever
+//the requested path is missing and no directory index exists either.
+//ErrorPages, if set, maps a status code to a path (again relative to the
+//backing fs.FS, eg. "404.html") that is served - with that status code - in
+//preference to the errorHandlers entry for the same code. Both are best set
+//via New and its functional options rather than by hand
 type StaticServer struct {
-	stat          StatFunc
-	readerfn      ReaderFunc
+	fsys          fs.FS
 	errorHandlers map[int]http.HandlerFunc
 	logger        *log.Logger
+
+	SPAFallback string
+	ErrorPages  map[int]string
+	Compression *CompressionOptions
+	//ClassifyError maps an error returned by the backing fs.FS to the HTTP
+	//status code it should be served as. If nil, defaultClassifyError is
+	//used, which maps fs.ErrNotExist to 404, fs.ErrPermission to 403 and
+	//everything else to 500
+	ClassifyError func(error) int
+}
+
+//FSStaticServer returns a StaticServer that serves static assets out of fsys,
+//the standard library's io/fs.FS abstraction. This lets StaticServer serve
+//embed.FS, os.DirFS, zip.Reader (via its fs.FS support) or any custom fs.FS
+//implementation without depending on the deprecated golang.org/x/tools/godoc/vfs
+//package. Use errorHandlers to provide custom http.HandlerFunc to handle
+//http.StatusNotFound and http.StatusInternalServerError or provide nil to use
+//default implementation. If a log.Logger is provided (ie. not nil), StaticServer
+//does verbose logging
+func FSStaticServer(fsys fs.FS, errorHandlers map[int]http.HandlerFunc, logger *log.Logger) StaticServer {
+	return StaticServer{
+		fsys:          fsys,
+		errorHandlers: setupErrorHandlers(errorHandlers),
+		logger:        logger,
+	}
 }
 
 //VFSStaticServer returns a StaticServer to serve a Godoc virtual file system
@@ -87,15 +124,13 @@ type StaticServer struct {
 //and http.StatusInternalServerError or provide nil to use default implementation
 //If a log.Logger is provided (ie. not nil), StaticServer does verbose logging
 func VFSStaticServer(f vfs.FileSystem, errorHandlers map[int]http.HandlerFunc, logger *log.Logger) StaticServer {
-	return StaticServer{
+	return FSStaticServer(statReaderFS{
 		stat: f.Lstat,
 		readerfn: func(name string) (io.ReadSeeker, error) {
 			rsc, err := f.Open(name)
 			return io.ReadSeeker(rsc), err
 		},
-		errorHandlers: setupErrorHandlers(errorHandlers),
-		logger:        logger,
-	}
+	}, errorHandlers, logger)
 }
 
 //OSSS is a convenience function to return a StaticServer based on the
@@ -129,12 +164,166 @@ func ZipSS(rc *zip.ReadCloser, name string, errorHandlers map[int]http.HandlerFu
 //RawStaticServer returns a static server where the caller supplies the StatFunc
 //and ReaderFunc functions that the StaticServer uses to find and serve content
 func RawStaticServer(stat StatFunc, readerfn ReaderFunc, errorHandlers map[int]http.HandlerFunc, logger *log.Logger) StaticServer {
-	return StaticServer{
-		stat:          stat,
-		readerfn:      readerfn,
-		errorHandlers: errorHandlers,
-		logger:        logger,
+	return FSStaticServer(statReaderFS{stat: stat, readerfn: readerfn}, errorHandlers, logger)
+}
+
+//fsPath converts an http.Request URL path into a path suitable for use with
+//io/fs.FS, which requires slash separated, rooted-free paths with "." for the
+//filesystem root. It also cleans the path so that it cannot possibly begin
+//with ../ to prevent access to files outside root path in case we're using a
+//real filesystem
+func fsPath(urlpath string) string {
+	clean := path.Clean("/" + urlpath)
+	if clean == "/" {
+		return "."
 	}
+	return clean[1:]
+}
+
+//openReadSeeker opens name on fsys and returns it alongside an io.ReadSeeker
+//suitable for http.ServeContent. os.DirFS and embed.FS already return a
+//ReadSeeker from Open, but we fall back to buffering the whole file in memory
+//for implementations that don't (eg. zip.Reader). The caller is responsible
+//for closing the returned fs.File
+func openReadSeeker(fsys fs.FS, name string) (fs.File, io.ReadSeeker, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if rds, ok := f.(io.ReadSeeker); ok {
+		return f, rds, nil
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, bytes.NewReader(data), nil
+}
+
+//statusOverrideWriter forces ServeContent's eventual WriteHeader call to use
+//status instead of whatever it would otherwise pick (normally 200, or 206 for
+//a satisfied range request), so that error pages served from the backing
+//fs.FS keep the status code they're standing in for
+type statusOverrideWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusOverrideWriter) WriteHeader(int) {
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+//stripConditionalHeaders returns a shallow copy of r with the headers that
+//drive http.ServeContent's conditional-GET and Range handling removed. It is
+//used for forced-status responses (eg. error pages), which must always be
+//served in full with their forced status rather than negotiated down to a
+//304 or a partial range response
+func stripConditionalHeaders(r *http.Request) *http.Request {
+	clone := r.Clone(r.Context())
+	for _, h := range []string{"If-Match", "If-None-Match", "If-Modified-Since", "If-Unmodified-Since", "Range"} {
+		clone.Header.Del(h)
+	}
+	return clone
+}
+
+//serveContent serves the file described by info and rds with the given
+//status code, using http.ServeContent so that clients still get sensible
+//Content-Type sniffing. For a status of http.StatusOK, an ETag is computed
+//and set before calling http.ServeContent so that it also honors
+//If-None-Match/If-Match and Range for us. Any other status is a forced
+//status (eg. an error page standing in for status) - statusOverrideWriter
+//can only override the status ServeContent writes, not undo a 304 or
+//partial range response it already committed to, so conditional/Range
+//headers are stripped from the request first and no ETag is set
+func (ss *StaticServer) serveContent(w http.ResponseWriter, r *http.Request, status int, info fs.FileInfo, rds io.ReadSeeker) {
+	if status != http.StatusOK {
+		w = &statusOverrideWriter{ResponseWriter: w, status: status}
+		http.ServeContent(w, stripConditionalHeaders(r), info.Name(), info.ModTime(), rds)
+		return
+	}
+
+	if etag, err := computeETag(info, rds); err == nil {
+		w.Header().Set("ETag", etag)
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	http.ServeContent(w, r, info.Name(), info.ModTime(), rds)
+}
+
+//defaultClassifyError maps an error returned by a fs.FS to the HTTP status
+//code it should be served as: fs.ErrNotExist to 404, fs.ErrPermission to 403
+//and anything else (I/O failures, corrupt archive entries etc.) to 500
+func defaultClassifyError(err error) int {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return http.StatusNotFound
+	case errors.Is(err, fs.ErrPermission):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+//classifyError delegates to ss.ClassifyError if set, else defaultClassifyError
+func (ss *StaticServer) classifyError(err error) int {
+	if ss.ClassifyError != nil {
+		return ss.ClassifyError(err)
+	}
+	return defaultClassifyError(err)
+}
+
+//serveFSError classifies err and routes it to serveError, logging the
+//underlying error via ss.logger whenever it is routed to a 5xx handler
+func (ss *StaticServer) serveFSError(w http.ResponseWriter, r *http.Request, err error) {
+	status := ss.classifyError(err)
+	if status >= http.StatusInternalServerError && ss.logger != nil {
+		ss.logger.Printf("StaticServer: %s. Returning status %d for :%s", err, status, r.URL.Path)
+	}
+	ss.serveError(w, r, status)
+}
+
+//serveError routes a status code to the matching entry in ErrorPages (served
+//from the backing fs.FS with that status code) if present, falling back to
+//errorHandlers otherwise
+func (ss *StaticServer) serveError(w http.ResponseWriter, r *http.Request, status int) {
+	if ss.logger != nil {
+		ss.logger.Printf("StaticServer: Returning status %d for :%s", status, r.URL.Path)
+	}
+
+	if page, ok := ss.ErrorPages[status]; ok {
+		if info, err := fs.Stat(ss.fsys, page); err == nil && !info.IsDir() {
+			if f, rds, err := openReadSeeker(ss.fsys, page); err == nil {
+				defer f.Close()
+				ss.serveContent(w, r, status, info, rds)
+				return
+			}
+		}
+	}
+
+	if handler, ok := ss.errorHandlers[status]; ok {
+		handler(w, r)
+		return
+	}
+	http.Error(w, http.StatusText(status), status)
+}
+
+//spaFallback looks up SPAFallback on the backing fs.FS, returning the path
+//and os.FileInfo to serve it with a 200 status, or an error if SPAFallback is
+//unset, missing or itself a directory
+func (ss *StaticServer) spaFallback() (string, fs.FileInfo, error) {
+	if ss.SPAFallback == "" {
+		return "", nil, fs.ErrNotExist
+	}
+	info, err := fs.Stat(ss.fsys, ss.SPAFallback)
+	if err != nil {
+		return "", nil, err
+	}
+	if info.IsDir() {
+		return "", nil, fs.ErrNotExist
+	}
+	return ss.SPAFallback, info, nil
 }
 
 func (ss *StaticServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -143,68 +332,62 @@ func (ss *StaticServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		ss.logger.Printf("StaticServer: Request for :%s", r.URL.Path)
 	}
 
-	// clean the path so that it cannot possibly begin with ../.
-	// to prevent access to files outside root path in case we're using real FS
-	reqpath := filepath.Clean("/" + r.URL.Path)
+	reqpath := fsPath(r.URL.Path)
 
 	//try to find the requested file
-	info, err := ss.stat(reqpath)
+	info, err := fs.Stat(ss.fsys, reqpath)
 	if err != nil {
-		if ss.logger != nil {
-			ss.logger.Printf("StaticServer: Error finding requested path. Returning http.StatusNotFound:%s", reqpath)
+		if ss.classifyError(err) != http.StatusNotFound {
+			ss.serveFSError(w, r, err)
+			return
+		}
+		if reqpath, info, err = ss.spaFallback(); err != nil {
+			ss.serveError(w, r, http.StatusNotFound)
+			return
 		}
-		ss.errorHandlers[http.StatusNotFound](w, r)
-		return
 	}
 
 	//we don't allow listing of directories. If the path was a directory, try
-	//to find an index.html in it else return an error
+	//to find an index.html in it else fall back to SPAFallback or an error
 	if info.IsDir() {
 		if ss.logger != nil {
 			ss.logger.Printf("StaticServer: The requested path was a directory. Trying to find index.html file in it:%s", reqpath)
 		}
-		reqpath = filepath.Join(reqpath, "index.html")
-		info, err = ss.stat(reqpath)
-		if err != nil {
-			if ss.logger != nil {
-				ss.logger.Printf("StaticServer: index.html was not found. Returning http.StatusNotFound:%s", reqpath)
-			}
-			ss.errorHandlers[http.StatusNotFound](w, r)
+		indexpath := path.Join(reqpath, "index.html")
+		indexinfo, ierr := fs.Stat(ss.fsys, indexpath)
+		switch {
+		case ierr != nil && ss.classifyError(ierr) != http.StatusNotFound:
+			ss.serveFSError(w, r, ierr)
 			return
-		}
-		//if index.html itself was a directory (however unlikely that is), just
-		//send a not found error message since we don't want to serve directories
-		//and don't want to get caught in a possible infinite recursion loop
-		if info.IsDir() {
-			if ss.logger != nil {
-				ss.logger.Printf("StaticServer: index.html is a folder. Returning http.StatusNotFound:%s", reqpath)
+		case ierr != nil || indexinfo.IsDir():
+			//if index.html itself was a directory (however unlikely that is),
+			//treat it the same as a missing index so we don't serve
+			//directories or get caught in a possible infinite recursion loop
+			if reqpath, info, err = ss.spaFallback(); err != nil {
+				ss.serveError(w, r, http.StatusNotFound)
+				return
 			}
-			ss.errorHandlers[http.StatusNotFound](w, r)
-			return
+		default:
+			reqpath, info = indexpath, indexinfo
 		}
 	}
 
-	//at this stage, reqpath should be a valid asset that is available. We
-	//try to get an io.Reader onto the file.
-	rds, err := ss.readerfn(reqpath)
+	//at this stage, reqpath should be a valid asset that is available. Try
+	//serving a compressed representation of it first, if configured
+	if ss.tryCompression(w, r, reqpath, info) {
+		return
+	}
+
+	//otherwise, try to open the file and serve it as-is
+	f, rds, err := openReadSeeker(ss.fsys, reqpath)
 	if err != nil {
-		if ss.logger != nil {
-			ss.logger.Printf("StaticServer: Error obtaining a reader to requested path. Returning http.StatusInternalServerError:%s", reqpath)
-		}
-		ss.errorHandlers[http.StatusInternalServerError](w, r)
+		ss.serveFSError(w, r, err)
 		return
 	}
+	defer f.Close()
 
 	if ss.logger != nil {
 		ss.logger.Printf("StaticServer: Calling ServeContent for:%s", reqpath)
 	}
-	//Now we have both a reader and an os.FileInfo on the asset. we call http.ServeContent
-	http.ServeContent(w, r, info.Name(), info.ModTime(), rds)
-
-	//if the ReaderSeeker defines a Close() method, call it to avoid dangling file handles
-	rdsc, ok := rds.(vfs.ReadSeekCloser)
-	if ok {
-		rdsc.Close()
-	}
-
+	ss.serveContent(w, r, http.StatusOK, info, rds)
 }
@@ -28,12 +28,7 @@ func main() {
 		return
 	}
 
-	server, err := staticserver.NewStaticServer(flag.Arg(0))
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
-		flag.Usage()
-		return
-	}
+	server := staticserver.New(staticserver.OSFS(flag.Arg(0)))
 
-	http.ListenAndServe(ipport, server)
+	http.ListenAndServe(ipport, &server)
 }
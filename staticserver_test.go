@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/srinathh/memfile"
@@ -128,6 +129,42 @@ func TestFS(t *testing.T) {
 	runTests(tests, ss, "TestFS", t)
 }
 
+func TestFSStaticServer(t *testing.T) {
+	mapfs := fstest.MapFS{
+		"index.html":     {Data: []byte("root index file")},
+		"sub/index.html": {Data: []byte("sub index file")},
+	}
+	ss := FSStaticServer(mapfs, nil, nil)
+	tests := map[string]response{
+		"http://www.example.com/":     response{200, "root index file"},
+		"http://www.example.com/sub/": response{200, "sub index file"},
+	}
+	runTests(tests, ss, "TestFSStaticServer", t)
+}
+
+func TestSPAFallback(t *testing.T) {
+	mapfs := fstest.MapFS{
+		"index.html": {Data: []byte("app shell")},
+	}
+	ss := New(mapfs, WithSPAFallback("index.html"))
+	tests := map[string]response{
+		"http://www.example.com/":           response{200, "app shell"},
+		"http://www.example.com/route/deep": response{200, "app shell"},
+	}
+	runTests(tests, ss, "TestSPAFallback", t)
+}
+
+func TestErrorPages(t *testing.T) {
+	mapfs := fstest.MapFS{
+		"404.html": {Data: []byte("custom not found page")},
+	}
+	ss := New(mapfs, WithErrorPages(map[int]string{http.StatusNotFound: "404.html"}))
+	tests := map[string]response{
+		"http://www.example.com/missing": response{404, "custom not found page"},
+	}
+	runTests(tests, ss, "TestErrorPages", t)
+}
+
 type response struct {
 	Code int
 	Body string